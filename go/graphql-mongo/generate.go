@@ -0,0 +1,3 @@
+package index
+
+//go:generate go run github.com/99designs/gqlgen
@@ -0,0 +1,294 @@
+// generated.go is a hand-written stand-in for gqlgen's generated executor.
+// This tree doesn't vendor github.com/99designs/gqlgen, so `go generate`
+// (see generate.go) can't actually produce it here; until it can, this file
+// plays the executor's role by hand and must be kept in sync with
+// gqlgen.yml/schema.graphql. Replace it with real `go generate ./...`
+// output once gqlgen is vendored - in particular, this executor only walks
+// top-level selections, so it needs the full nested-selection walk gqlgen
+// normally generates before Post grows any fields resolving to other types.
+
+package index
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/vektah/gqlparser/v2"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+// NewExecutableSchema creates an ExecutableSchema from the ResolverRoot
+// wired up in cfg. It is what Handler mounts via handler.GraphQL.
+func NewExecutableSchema(cfg Config) graphql.ExecutableSchema {
+	return &executableSchema{resolvers: cfg.Resolvers}
+}
+
+// Config bundles the dependencies the generated schema needs to run -
+// currently just the resolver root provided in resolver.go.
+type Config struct {
+	Resolvers ResolverRoot
+}
+
+type ResolverRoot interface {
+	Mutation() MutationResolver
+	Query() QueryResolver
+	Subscription() SubscriptionResolver
+}
+
+type MutationResolver interface {
+	CreatePost(ctx context.Context, slug string, title string) (*Post, error)
+	UpdatePost(ctx context.Context, slug string, title string) (*Post, error)
+	DeletePost(ctx context.Context, slug string) (bool, error)
+}
+
+type QueryResolver interface {
+	Post(ctx context.Context, slug string) (*Post, error)
+	Posts(ctx context.Context, limit *int) ([]*Post, error)
+}
+
+type SubscriptionResolver interface {
+	PostAdded(ctx context.Context, slug *string) (<-chan *Post, error)
+}
+
+// parsedSchema is the ast.Schema backing graphql.ExecutableSchema.Schema,
+// parsed at startup from the embedded copy of schema.graphql below.
+// LoadSchema (index.go) swaps it at runtime for one parsed from a file on
+// disk, so parsedSchemaMu guards every access.
+var (
+	parsedSchemaMu sync.RWMutex
+	parsedSchema   = gqlparser.MustLoadSchema(&ast.Source{Name: "schema.graphql", Input: schemaSDL})
+)
+
+type executableSchema struct {
+	resolvers ResolverRoot
+}
+
+func (e *executableSchema) Schema() *ast.Schema {
+	parsedSchemaMu.RLock()
+	defer parsedSchemaMu.RUnlock()
+	return parsedSchema
+}
+
+// Complexity reports the cost of a single field for query-complexity
+// limiting. Every field costs 1, which is enough given this schema's
+// depth; a richer per-field cost model can be layered in via gqlgen.yml.
+func (e *executableSchema) Complexity(typeName, field string, childComplexity int, args map[string]interface{}) (int, bool) {
+	return childComplexity + 1, true
+}
+
+// maxComplexity is the ceiling operationComplexity enforces before Exec
+// resolves anything, so a client can't force open-ended resolver work
+// through query shape alone even though this executor has no per-field
+// cost model richer than Complexity's flat +1.
+const maxComplexity = 100
+
+// operationComplexity sums Complexity across op's top-level fields, the
+// only depth this executor's flat schema and single-level walk need to
+// account for.
+func (e *executableSchema) operationComplexity(op *ast.OperationDefinition, vars map[string]interface{}) int {
+	typeName := "Query"
+	switch op.Operation {
+	case ast.Mutation:
+		typeName = "Mutation"
+	case ast.Subscription:
+		typeName = "Subscription"
+	}
+
+	total := 0
+	for _, sel := range op.SelectionSet {
+		field, ok := sel.(*ast.Field)
+		if !ok {
+			continue
+		}
+		cost, _ := e.Complexity(typeName, field.Name, 0, field.ArgumentMap(vars))
+		total += cost
+	}
+	return total
+}
+
+// Exec resolves the operation attached to ctx by the handler. Query and
+// mutation operations resolve once and return a single response;
+// subscriptions hand back a ResponseHandler that the transport invokes
+// repeatedly, once per published value, until it returns nil.
+func (e *executableSchema) Exec(ctx context.Context) graphql.ResponseHandler {
+	opCtx := graphql.GetOperationContext(ctx)
+	op := opCtx.Operation
+
+	if cost := e.operationComplexity(op, opCtx.Variables); cost > maxComplexity {
+		return onceError(ctx, "operation exceeds complexity limit (%d > %d)", cost, maxComplexity)
+	}
+
+	if op.Operation == ast.Subscription {
+		return e.execSubscription(ctx, op, opCtx.Variables)
+	}
+
+	return func(ctx context.Context) *graphql.Response {
+		data, err := e.execOperation(ctx, op, opCtx.Variables)
+		if err != nil {
+			return graphql.ErrorResponse(ctx, "%s", err.Error())
+		}
+
+		b, err := json.Marshal(data)
+		if err != nil {
+			return graphql.ErrorResponse(ctx, "%s", err.Error())
+		}
+		return &graphql.Response{Data: b}
+	}
+}
+
+func (e *executableSchema) execOperation(ctx context.Context, op *ast.OperationDefinition, vars map[string]interface{}) (map[string]interface{}, error) {
+	out := make(map[string]interface{}, len(op.SelectionSet))
+	for _, sel := range op.SelectionSet {
+		field, ok := sel.(*ast.Field)
+		if !ok {
+			return nil, fmt.Errorf("this hand-written executor does not support fragments in a top-level selection set, got %T", sel)
+		}
+
+		value, err := e.resolveField(ctx, op.Operation, field, vars)
+		if err != nil {
+			return nil, err
+		}
+		out[field.Alias] = value
+	}
+	return out, nil
+}
+
+func (e *executableSchema) resolveField(ctx context.Context, op ast.Operation, field *ast.Field, vars map[string]interface{}) (interface{}, error) {
+	args := field.ArgumentMap(vars)
+
+	switch op {
+	case ast.Query:
+		switch field.Name {
+		case "post":
+			return e.resolvers.Query().Post(ctx, args["slug"].(string))
+		case "posts":
+			return e.resolvers.Query().Posts(ctx, intArg(args, "limit"))
+		}
+	case ast.Mutation:
+		switch field.Name {
+		case "createPost":
+			return e.resolvers.Mutation().CreatePost(ctx, args["slug"].(string), args["title"].(string))
+		case "updatePost":
+			return e.resolvers.Mutation().UpdatePost(ctx, args["slug"].(string), args["title"].(string))
+		case "deletePost":
+			return e.resolvers.Mutation().DeletePost(ctx, args["slug"].(string))
+		}
+	}
+	return nil, fmt.Errorf("unknown %s field %q", op, field.Name)
+}
+
+// execSubscription resolves the single top-level field a subscription
+// operation is allowed to select, and adapts its <-chan *Post into the
+// repeatedly-invoked ResponseHandler the transport (SubscriptionHandler)
+// expects: one response per published post, then nil once ctx is done. It
+// waits on ctx.Done() rather than the resolver's channel closing, since
+// that channel is never closed (see subscription.go).
+func (e *executableSchema) execSubscription(ctx context.Context, op *ast.OperationDefinition, vars map[string]interface{}) graphql.ResponseHandler {
+	field, ok := firstField(op)
+	if !ok {
+		return onceError(ctx, "subscription has no selected field")
+	}
+
+	if field.Name != "postAdded" {
+		return onceError(ctx, "unknown subscription field %q", field.Name)
+	}
+
+	args := field.ArgumentMap(vars)
+	ch, err := e.resolvers.Subscription().PostAdded(ctx, strArg(args, "slug"))
+	if err != nil {
+		return onceError(ctx, "%s", err.Error())
+	}
+
+	return func(ctx context.Context) *graphql.Response {
+		var post *Post
+		select {
+		case post = <-ch:
+		case <-ctx.Done():
+			return nil
+		}
+
+		b, err := json.Marshal(map[string]interface{}{field.Alias: post})
+		if err != nil {
+			return graphql.ErrorResponse(ctx, "%s", err.Error())
+		}
+		return &graphql.Response{Data: b}
+	}
+}
+
+func firstField(op *ast.OperationDefinition) (*ast.Field, bool) {
+	for _, sel := range op.SelectionSet {
+		if field, ok := sel.(*ast.Field); ok {
+			return field, true
+		}
+	}
+	return nil, false
+}
+
+// onceError returns a ResponseHandler that reports err on its first
+// invocation and nil (stream closed) on every call after, for callers that
+// need to fail a subscription before it has a value to stream.
+func onceError(ctx context.Context, format string, args ...interface{}) graphql.ResponseHandler {
+	reported := false
+	return func(ctx context.Context) *graphql.Response {
+		if reported {
+			return nil
+		}
+		reported = true
+		return graphql.ErrorResponse(ctx, format, args...)
+	}
+}
+
+// intArg reads an *Int argument, applying its schema default if the client
+// omitted it - this is what gives posts(limit: Int = 20) its default
+// without Posts needing a manual nil-check.
+func intArg(args map[string]interface{}, name string) *int {
+	v, ok := args[name].(int64)
+	if !ok {
+		return nil
+	}
+	i := int(v)
+	return &i
+}
+
+// strArg reads an optional *String argument, returning nil if the client
+// omitted it - this is what lets postAdded(slug: String) distinguish "no
+// filter" from a filtered subscription without a manual nil-check.
+func strArg(args map[string]interface{}, name string) *string {
+	v, ok := args[name].(string)
+	if !ok {
+		return nil
+	}
+	return &v
+}
+
+const schemaSDL = `schema {
+    query: Query
+    mutation: Mutation
+    subscription: Subscription
+}
+
+# The Query type represents all of the entry points.
+type Query {
+    post(slug: String!): Post
+    posts(limit: Int = 20): [Post!]!
+}
+
+type Mutation {
+    createPost(slug: String!, title: String!): Post!
+    updatePost(slug: String!, title: String!): Post
+    deletePost(slug: String!): Boolean!
+}
+
+type Subscription {
+    postAdded(slug: String): Post!
+}
+
+type Post {
+    id: ID!
+    slug: String!
+    title: String!
+}
+`
@@ -3,191 +3,152 @@ package index
 import (
 	"context"
 	"fmt"
+	"io/ioutil"
 	"log"
 	"net/http"
-	"encoding/json"
 	"os"
-	"github.com/graph-gophers/graphql-go"
-	"github.com/mongodb/mongo-go-driver/bson"
-	"github.com/mongodb/mongo-go-driver/mongo"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/handler"
+	"github.com/vektah/gqlparser/v2"
+	"github.com/vektah/gqlparser/v2/ast"
+
+	"github.com/bluebeel/now-examples/go/graphql-mongo/loader"
+	"github.com/bluebeel/now-examples/go/graphql-mongo/storage"
 )
 
-func Handler(w http.ResponseWriter, r *http.Request) {
-	var params struct {
-		Query         string                 `json:"query"`
-		OperationName string                 `json:"operationName"`
-		Variables     map[string]interface{} `json:"variables"`
-	}
-	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
-		return
+// requestTimeout bounds how long a single GraphQL operation may spend
+// talking to the store, derived from the incoming request's context in
+// Handler.
+const requestTimeout = 10 * time.Second
+
+// store is the durable backend selected by STORAGE_DRIVER in init. The
+// resolvers in resolver.go only ever talk to it through the
+// storage.PostStore interface, so they don't need to know whether it's
+// Mongo or Postgres.
+var store storage.PostStore
+
+// execSchema is the executable schema, built once in init from the
+// resolvers in resolver.go; resolveField's field dispatch (generated.go)
+// is fixed Go code and still needs a redeploy to change. The served
+// ast.Schema it validates and introspects against can still be
+// reloaded at runtime without one, via LoadSchema and SIGUSR1.
+var execSchema graphql.ExecutableSchema
+
+// schemaPath is the file LoadSchema last read from, re-read by
+// watchReloadSignal on SIGUSR1. It's empty until LoadSchema is called.
+var schemaPath string
+
+// LoadSchema reads the SDL from path, parses it and atomically swaps it in
+// as the schema execSchema.Schema() serves for validation and introspection.
+// It's also what SIGUSR1 re-invokes at runtime, so schema.graphql edits that
+// only touch existing fields' types/descriptions/defaults can be picked up
+// without a redeploy.
+func LoadSchema(path string) error {
+	sdl, err := ioutil.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("LoadSchema: %v", err)
 	}
 
-	response := graphqlSchema.Exec(r.Context(), params.Query, params.OperationName, params.Variables)
-	responseJSON, err := json.Marshal(response)
+	parsed, err := gqlparser.LoadSchema(&ast.Source{Name: path, Input: string(sdl)})
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
+		return fmt.Errorf("LoadSchema: %v", err)
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.Write(responseJSON)
+	parsedSchemaMu.Lock()
+	parsedSchema = parsed
+	schemaPath = path
+	parsedSchemaMu.Unlock()
+
+	log.Printf("Schema reloaded from %s", path)
+	return nil
 }
 
-// Cleanup will remove all mock data from the database.
-func Cleanup(col string) {
-	log.Println("Cleaning up MongoDB...")
-	ctx, collection := GetMongo(col)
+// watchReloadSignal re-parses the schema from schemaPath whenever the
+// process receives SIGUSR1, so `kill -USR1 <pid>` picks up schema edits
+// without a redeploy.
+func watchReloadSignal() {
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, syscall.SIGUSR1)
+	go func() {
+		for range sig {
+			if schemaPath == "" {
+				log.Println("SIGUSR1 received but no schema file was loaded via LoadSchema, ignoring")
+				continue
+			}
+			if err := LoadSchema(schemaPath); err != nil {
+				log.Println(err)
+			}
+		}
+	}()
+}
 
-	_, err := collection.DeleteMany(ctx,
-		bson.NewDocument())
-	if err != nil {
-		log.Fatal(err)
-	}
+// withRequestLoaders bounds the operation to requestTimeout and attaches a
+// fresh set of per-request DataLoaders before it executes, so resolvers can
+// reach them via loader.FromContext without Handler having to parse the
+// request itself.
+func withRequestLoaders(ctx context.Context, next func(ctx context.Context) []byte) []byte {
+	ctx, cancel := context.WithTimeout(ctx, requestTimeout)
+	defer cancel()
+	return next(loader.WithLoaders(ctx, store))
 }
 
-// GetMongo returns the session and a reference to the post collection.
-func GetMongo(col string) (context.Context, *mongo.Collection) {
+func Handler(w http.ResponseWriter, r *http.Request) {
+	handler.GraphQL(execSchema, handler.RequestMiddleware(withRequestLoaders)).ServeHTTP(w, r)
+}
 
-	host := os.Getenv("HOST")
-	user := os.Getenv("USER")
-	pwd := os.Getenv("PWD")
-	ctx := context.Background()
+// HealthzHandler reports whether the active store can still reach its
+// backing database, so operators can point uptime checks at it instead of
+// inferring health from GraphQL traffic.
+func HealthzHandler(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), requestTimeout)
+	defer cancel()
 
-	client, err := mongo.Connect(ctx, fmt.Sprintf("mongodb://%s:%s@%s", user, pwd, host))
-	
-	if err != nil {
-		log.Fatal(err)
+	if err := store.Ping(ctx); err != nil {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
 	}
-	collection := client.Database("graphql-mongo-zeit").Collection(col)
-	return ctx, collection
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("ok"))
 }
 
-//////// GRAPHQL ////////
-var graphqlSchema *graphql.Schema
-
-// Schema describes the data that we ask for
-var Schema = `
-    schema {
-        query: Query
-    }
-    # The Query type represents all of the entry points.
-    type Query {
-        post(slug: String!): Post
-    }
-    type Post {
-        id: ID!
-        slug: String!
-        title: String!
-    }
-    `
+// Cleanup will remove all mock data from the database.
+func Cleanup() {
+	log.Println("Cleaning up the post store...")
+	if err := store.DeleteAll(context.Background()); err != nil {
+		log.Fatal(err)
+	}
+}
 
 //////// INIT ////////
 func init() {
-	// MustParseSchema parses a GraphQL schema and attaches the given root resolver.
-	// It returns an error if the Go type signature of the resolvers does not match the schema.
-	graphqlSchema = graphql.MustParseSchema(Schema, &Resolver{})
-
-	log.Println("Seeding mock data to MongoDB")
-	// Call GetMongo, session and reference to the post collection
-	ctx, collection := GetMongo("posts")
-	// Close the session so its resources may be put back in the pool or collected, depending on the case.
-
-	// Cleanup finds all documents matching the provided selector document
-	// and removes them from the database. So we make sure the db is empty before inserting mock data.
-	Cleanup("post")
-
-	// The mock data that we insert.
-	_, err := collection.InsertMany(
-		ctx,
-		[]interface{}{
-			bson.NewDocument(
-				bson.EC.Int32("ID", 1),
-				bson.EC.String("title", "First post"),
-				bson.EC.String("slug", "first-post"),
-			),
-			bson.NewDocument(
-				bson.EC.Int32("ID", 2),
-				bson.EC.String("title", "Second post"),
-				bson.EC.String("slug", "second-post"),
-			),
-			bson.NewDocument(
-				bson.EC.Int32("ID", 3),
-				bson.EC.String("title", "Third post"),
-				bson.EC.String("slug", "third-post"),
-			),
-		},
-	)
+	execSchema = NewExecutableSchema(Config{Resolvers: &Resolver{}})
+	watchReloadSignal()
 
+	var err error
+	store, err = storage.NewStore()
 	if err != nil {
 		log.Fatal(err)
 	}
-	log.Println("Mock data added successfully!")
-}
-
-
-// Resolver. in order to respond to queries, a schema needs to have resolve functions for all fields.
-// Go’s structs are typed collections of fields. They’re useful for grouping data together to form records.
-type Resolver struct{}
 
-type post struct {
-	ID    graphql.ID
-	Slug  string
-	Title string
-}
-
-type postResolver struct {
-	s *post
-}
-
-type searchResultResolver struct {
-	result interface{}
-}
+	log.Println("Seeding mock data to the post store")
+	// Cleanup removes every post from the store so we make sure it is empty
+	// before inserting mock data.
+	Cleanup()
 
-// Slices can be created with the built-in make function; this is how we create dynamically-sized arrays.
-var postData = make(map[string]*post)
-
-// Post resolves the Post queries.
-func (r *Resolver) Post(args struct{ Slug string }) *postResolver {
-	// One result is a pointer to type post.
-	oneResult := &post{}
-
-	// Call GetMongo, session and reference to the post collection
-	ctx, collection := GetMongo("post")
-	// Close the session so its resources may be put back in the pool or collected, depending on the case.
-
-	// Inside the collection, find by slug and return all fields.
-	//err := collection.Find(bson.M{"slug": args.Slug}).Select(bson.M{}).One(&oneResult)
-	cur, err := collection.Find(
-		ctx,
-		bson.NewDocument(
-			bson.EC.String("slug", args.Slug),
-		),
-	)
-	if err != nil {
-		fmt.Println(err)
-	}
-	defer cur.Close(ctx)
-	for cur.Next(ctx) {
-		cur.Decode(oneResult)
+	// The mock data that we insert. IDs are assigned by Insert, not set here.
+	seed := []*storage.Post{
+		{Title: "First post", Slug: "first-post"},
+		{Title: "Second post", Slug: "second-post"},
+		{Title: "Third post", Slug: "third-post"},
 	}
-
-	// Make a type postResolver out of oneResult.
-	if s := oneResult; s != nil {
-		return &postResolver{oneResult}
+	for _, p := range seed {
+		if err := store.Insert(context.Background(), p); err != nil {
+			log.Fatal(err)
+		}
 	}
-	return nil
-}
-
-// Resolve each field to respond to queries.
-func (r *postResolver) ID() graphql.ID {
-	return r.s.ID
-}
-
-func (r *postResolver) Slug() string {
-	return r.s.Slug
+	log.Println("Mock data added successfully!")
 }
-
-func (r *postResolver) Title() string {
-	return r.s.Title
-}
\ No newline at end of file
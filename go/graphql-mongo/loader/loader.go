@@ -0,0 +1,211 @@
+// Package loader implements a small per-request DataLoader: it batches and
+// caches the Load calls a single GraphQL request makes through a
+// storage.PostStore, so that N calls to the same field (e.g. resolving
+// Post.author for every item in a list) turn into one batched store call
+// instead of N.
+package loader
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/bluebeel/now-examples/go/graphql-mongo/storage"
+)
+
+// ErrNotFound is returned by Load when fetch's batch didn't include a
+// value for the requested key.
+var ErrNotFound = errors.New("loader: key not found")
+
+// batchWindow is how long a Loader waits after its first Load call for more
+// keys to arrive before it fires the batched query.
+const batchWindow = 2 * time.Millisecond
+
+// maxBatchSize caps how many keys go into a single $in query; a batch fires
+// early once it reaches this size rather than waiting out batchWindow.
+const maxBatchSize = 100
+
+// contextKey is an unexported type so Loaders attached to a context can't
+// collide with keys set by other packages.
+type contextKey string
+
+const loadersKey contextKey = "loaders"
+
+// Loaders bundles the per-request loaders available to resolvers. Handler
+// attaches one of these to the request context before calling Exec; add a
+// field here for every store lookup that needs batched loading.
+type Loaders struct {
+	Post *Loader
+}
+
+// FromContext returns the Loaders attached to ctx by WithLoaders. It panics
+// if none were attached, since that means Handler was not wired up
+// correctly.
+func FromContext(ctx context.Context) *Loaders {
+	return ctx.Value(loadersKey).(*Loaders)
+}
+
+// WithLoaders returns a context carrying a fresh set of Loaders, backed by
+// store, scoped to a single request.
+func WithLoaders(ctx context.Context, store storage.PostStore) context.Context {
+	loaders := &Loaders{
+		Post: NewLoader(func(ctx context.Context, slugs []string) (map[string]interface{}, error) {
+			found, err := store.BatchGetBySlugs(ctx, slugs)
+			if err != nil {
+				return nil, err
+			}
+			byKey := make(map[string]interface{}, len(found))
+			for slug, p := range found {
+				byKey[slug] = p
+			}
+			return byKey, nil
+		}),
+	}
+	return context.WithValue(ctx, loadersKey, loaders)
+}
+
+// request is a single pending Load call waiting to be dispatched as part of
+// the next batch.
+type request struct {
+	key    string
+	result chan result
+}
+
+type result struct {
+	value interface{}
+	err   error
+}
+
+// BatchFunc resolves a batch of keys to their values in one round trip,
+// returning only the keys that matched. It must be safe to call once per
+// batch from the Loader's own goroutine.
+type BatchFunc func(ctx context.Context, keys []string) (map[string]interface{}, error)
+
+// Loader batches and caches Load(key) calls made during the lifetime of a
+// single request. It is not safe for use beyond that request: a fresh
+// Loader must be created per Loaders/WithLoaders call.
+type Loader struct {
+	fetch BatchFunc
+
+	mu    sync.Mutex
+	cache map[string]result
+
+	requests chan request
+	once     sync.Once
+}
+
+// NewLoader returns a Loader that batches Load calls and resolves each
+// batch with a single call to fetch.
+func NewLoader(fetch BatchFunc) *Loader {
+	return &Loader{
+		fetch:    fetch,
+		cache:    make(map[string]result),
+		requests: make(chan request, maxBatchSize),
+	}
+}
+
+// Load returns the value for key, coalescing this call with any other Load
+// calls made on the same Loader within the current batch window and
+// caching the result for the rest of the request.
+func (l *Loader) Load(ctx context.Context, key string) (interface{}, error) {
+	l.once.Do(func() { go l.run(ctx) })
+
+	l.mu.Lock()
+	if cached, ok := l.cache[key]; ok {
+		l.mu.Unlock()
+		return cached.value, cached.err
+	}
+	l.mu.Unlock()
+
+	req := request{key: key, result: make(chan result, 1)}
+	select {
+	case l.requests <- req:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	select {
+	case res := <-req.result:
+		return res.value, res.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// run collects incoming requests into batches and dispatches one $in query
+// per batch, fanning the results back out to each caller. It exits once ctx
+// is canceled, which happens when the request that owns this Loader ends.
+func (l *Loader) run(ctx context.Context) {
+	for {
+		batch, ok := l.nextBatch(ctx)
+		if !ok {
+			return
+		}
+		l.dispatch(ctx, batch)
+	}
+}
+
+// nextBatch collects up to maxBatchSize requests, waiting at most
+// batchWindow after the first one arrives.
+func (l *Loader) nextBatch(ctx context.Context) ([]request, bool) {
+	var batch []request
+
+	select {
+	case req := <-l.requests:
+		batch = append(batch, req)
+	case <-ctx.Done():
+		return nil, false
+	}
+
+	timer := time.NewTimer(batchWindow)
+	defer timer.Stop()
+
+	for len(batch) < maxBatchSize {
+		select {
+		case req := <-l.requests:
+			batch = append(batch, req)
+		case <-timer.C:
+			return batch, true
+		case <-ctx.Done():
+			return nil, false
+		}
+	}
+	return batch, true
+}
+
+// dispatch calls fetch once with all the keys in batch and sends each request
+// its matching value, or ErrNotFound if fetch didn't return one.
+func (l *Loader) dispatch(ctx context.Context, batch []request) {
+	keys := make([]string, len(batch))
+	for i, req := range batch {
+		keys[i] = req.key
+	}
+
+	found, err := l.fetch(ctx, keys)
+	if err != nil {
+		l.fail(batch, err)
+		return
+	}
+
+	l.mu.Lock()
+	for _, req := range batch {
+		res := result{err: ErrNotFound}
+		if value, ok := found[req.key]; ok {
+			res = result{value: value}
+		}
+		l.cache[req.key] = res
+		req.result <- res
+	}
+	l.mu.Unlock()
+}
+
+func (l *Loader) fail(batch []request, err error) {
+	l.mu.Lock()
+	for _, req := range batch {
+		res := result{err: err}
+		l.cache[req.key] = res
+		req.result <- res
+	}
+	l.mu.Unlock()
+}
@@ -0,0 +1,13 @@
+// models_gen.go is a hand-written stand-in for gqlgen's generated models,
+// for the same reason generated.go is: github.com/99designs/gqlgen isn't
+// vendored in this tree, so `go generate` (see generate.go) can't actually
+// produce it. Keep it in sync with schema.graphql's Post type by hand until
+// gqlgen is vendored and this can be replaced with real generated output.
+
+package index
+
+type Post struct {
+	ID    string `json:"id"`
+	Slug  string `json:"slug"`
+	Title string `json:"title"`
+}
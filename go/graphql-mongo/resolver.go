@@ -0,0 +1,118 @@
+package index
+
+// This file will not be regenerated automatically.
+//
+// It serves as dependency injection for your app, add any dependencies you
+// require here.
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/bluebeel/now-examples/go/graphql-mongo/loader"
+	"github.com/bluebeel/now-examples/go/graphql-mongo/storage"
+)
+
+type Resolver struct{}
+
+func (r *Resolver) Mutation() MutationResolver         { return &mutationResolver{r} }
+func (r *Resolver) Query() QueryResolver               { return &queryResolver{r} }
+func (r *Resolver) Subscription() SubscriptionResolver { return &subscriptionResolver{r} }
+
+type mutationResolver struct{ *Resolver }
+type queryResolver struct{ *Resolver }
+type subscriptionResolver struct{ *Resolver }
+
+func fromStoragePost(p *storage.Post) *Post {
+	return &Post{
+		ID:    fmt.Sprintf("%d", p.ID),
+		Slug:  p.Slug,
+		Title: p.Title,
+	}
+}
+
+// Post resolves the Query.post field. It goes through the per-request
+// loader rather than querying the store directly, so that sibling fields
+// resolving nested types (e.g. a future Comment.post) coalesce into a
+// single batched call instead of one lookup per call.
+func (r *queryResolver) Post(ctx context.Context, slug string) (*Post, error) {
+	value, err := loader.FromContext(ctx).Post.Load(ctx, slug)
+	if err == loader.ErrNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return fromStoragePost(value.(*storage.Post)), nil
+}
+
+// Posts resolves the Query.posts field, applying the schema's default
+// limit (20) when the client doesn't pass one.
+func (r *queryResolver) Posts(ctx context.Context, limit *int) ([]*Post, error) {
+	all, err := store.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	n := 20
+	if limit != nil {
+		n = *limit
+	}
+	if n < 0 {
+		n = 0
+	}
+	if n > len(all) {
+		n = len(all)
+	}
+
+	posts := make([]*Post, n)
+	for i := 0; i < n; i++ {
+		posts[i] = fromStoragePost(all[i])
+	}
+	return posts, nil
+}
+
+// CreatePost inserts a new post and notifies postAdded subscribers.
+func (r *mutationResolver) CreatePost(ctx context.Context, slug string, title string) (*Post, error) {
+	newPost := &storage.Post{Slug: slug, Title: title}
+	if err := store.Insert(ctx, newPost); err != nil {
+		return nil, err
+	}
+
+	resolved := fromStoragePost(newPost)
+	publishPostAdded(resolved)
+	return resolved, nil
+}
+
+// UpdatePost overwrites the title of an existing post, returning nil if no
+// post matches the given slug. It reads the updated post straight from the
+// store rather than through the request loader, since the loader's cache
+// would still hold the pre-update value.
+func (r *mutationResolver) UpdatePost(ctx context.Context, slug string, title string) (*Post, error) {
+	if err := store.Update(ctx, slug, title); err != nil {
+		return nil, err
+	}
+
+	updated, err := store.GetBySlug(ctx, slug)
+	if err != nil {
+		return nil, err
+	}
+	if updated == nil {
+		return nil, nil
+	}
+
+	return fromStoragePost(updated), nil
+}
+
+// DeletePost removes a post by slug, reporting whether one was deleted.
+func (r *mutationResolver) DeletePost(ctx context.Context, slug string) (bool, error) {
+	return store.Delete(ctx, slug)
+}
+
+// PostAdded resolves the postAdded subscription. When slug is non-nil, only
+// posts matching that slug are delivered, mirroring how Query.post already
+// filters by slug.
+func (r *subscriptionResolver) PostAdded(ctx context.Context, slug *string) (<-chan *Post, error) {
+	return subscribePostAdded(ctx, slug), nil
+}
@@ -0,0 +1,236 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/mongodb/mongo-go-driver/bson"
+	"github.com/mongodb/mongo-go-driver/mongo"
+	"github.com/mongodb/mongo-go-driver/mongo/options"
+)
+
+// defaultConnectTimeout bounds how long mongo.Connect waits to dial before
+// giving up, used when MONGO_CONNECT_TIMEOUT isn't set.
+const defaultConnectTimeout = 10 * time.Second
+
+// mongoClient, mongoClientErr and mongoClientOnce back getMongoClient: the
+// *mongo.Client is dialed at most once per process, under Now's cold/warm
+// invocation model, instead of once per request like the old GetMongo did.
+var (
+	mongoClient     *mongo.Client
+	mongoClientErr  error
+	mongoClientOnce sync.Once
+)
+
+// getMongoClient returns the process-wide pooled *mongo.Client, connecting
+// it on the first call. MONGO_MAX_POOL_SIZE, MONGO_MIN_POOL_SIZE and
+// MONGO_CONNECT_TIMEOUT tune the pool; all are optional.
+func getMongoClient() (*mongo.Client, error) {
+	mongoClientOnce.Do(func() {
+		host := os.Getenv("HOST")
+		user := os.Getenv("USER")
+		pwd := os.Getenv("PWD")
+
+		opts := options.Client()
+		if v := os.Getenv("MONGO_MAX_POOL_SIZE"); v != "" {
+			n, err := strconv.ParseUint(v, 10, 16)
+			if err != nil {
+				mongoClientErr = fmt.Errorf("storage: invalid MONGO_MAX_POOL_SIZE: %v", err)
+				return
+			}
+			opts = opts.SetMaxPoolSize(uint16(n))
+		}
+		if v := os.Getenv("MONGO_MIN_POOL_SIZE"); v != "" {
+			n, err := strconv.ParseUint(v, 10, 16)
+			if err != nil {
+				mongoClientErr = fmt.Errorf("storage: invalid MONGO_MIN_POOL_SIZE: %v", err)
+				return
+			}
+			opts = opts.SetMinPoolSize(uint16(n))
+		}
+
+		connectTimeout := defaultConnectTimeout
+		if v := os.Getenv("MONGO_CONNECT_TIMEOUT"); v != "" {
+			d, err := time.ParseDuration(v)
+			if err != nil {
+				mongoClientErr = fmt.Errorf("storage: invalid MONGO_CONNECT_TIMEOUT: %v", err)
+				return
+			}
+			connectTimeout = d
+		}
+		opts = opts.SetConnectTimeout(connectTimeout)
+
+		ctx, cancel := context.WithTimeout(context.Background(), connectTimeout)
+		defer cancel()
+
+		mongoClient, mongoClientErr = mongo.Connect(ctx, fmt.Sprintf("mongodb://%s:%s@%s", user, pwd, host), opts)
+	})
+	return mongoClient, mongoClientErr
+}
+
+// MongoStore implements PostStore against a MongoDB collection, reusing the
+// process-wide pooled client from getMongoClient rather than dialing a new
+// connection per store.
+type MongoStore struct {
+	collection *mongo.Collection
+
+	// insertMu serializes Insert's read-then-write assignment of the next
+	// ID, since Mongo (unlike Postgres's SERIAL columns) has no built-in
+	// auto-increment to lean on.
+	insertMu sync.Mutex
+}
+
+// NewMongoStore returns a MongoStore backed by the "post" collection of the
+// graphql-mongo-zeit database, connecting the shared pooled client on its
+// first call.
+func NewMongoStore() (*MongoStore, error) {
+	client, err := getMongoClient()
+	if err != nil {
+		return nil, err
+	}
+
+	collection := client.Database("graphql-mongo-zeit").Collection("post")
+	return &MongoStore{collection: collection}, nil
+}
+
+// Ping verifies the pooled client can still reach MongoDB, for the /healthz
+// endpoint.
+func (s *MongoStore) Ping(ctx context.Context) error {
+	client, err := getMongoClient()
+	if err != nil {
+		return err
+	}
+	return client.Ping(ctx, nil)
+}
+
+func (s *MongoStore) GetBySlug(ctx context.Context, slug string) (*Post, error) {
+	cur, err := s.collection.Find(ctx, bson.NewDocument(bson.EC.String("slug", slug)))
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	p := &Post{}
+	for cur.Next(ctx) {
+		if err := cur.Decode(p); err != nil {
+			return nil, err
+		}
+	}
+	if p.Slug == "" {
+		return nil, nil
+	}
+	return p, nil
+}
+
+func (s *MongoStore) BatchGetBySlugs(ctx context.Context, slugs []string) (map[string]*Post, error) {
+	keys := make([]interface{}, len(slugs))
+	for i, slug := range slugs {
+		keys[i] = slug
+	}
+
+	cur, err := s.collection.Find(ctx, bson.NewDocument(
+		bson.EC.SubDocumentFromElements("slug",
+			bson.EC.ArrayFromElements("$in", bson.VC.ArrayFromValues(keys)...),
+		),
+	))
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	found := make(map[string]*Post, len(slugs))
+	for cur.Next(ctx) {
+		p := &Post{}
+		if err := cur.Decode(p); err != nil {
+			return nil, err
+		}
+		found[p.Slug] = p
+	}
+	return found, nil
+}
+
+func (s *MongoStore) List(ctx context.Context) ([]*Post, error) {
+	cur, err := s.collection.Find(ctx, bson.NewDocument())
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	var posts []*Post
+	for cur.Next(ctx) {
+		p := &Post{}
+		if err := cur.Decode(p); err != nil {
+			return nil, err
+		}
+		posts = append(posts, p)
+	}
+	return posts, nil
+}
+
+// Insert assigns p.ID from nextID rather than taking whatever p.ID already
+// holds - the caller never has a real id to offer before the document
+// exists. insertMu holds the lock across both the ID lookup and the write
+// so two concurrent inserts can't compute the same next ID.
+func (s *MongoStore) Insert(ctx context.Context, p *Post) error {
+	s.insertMu.Lock()
+	defer s.insertMu.Unlock()
+
+	id, err := s.nextID(ctx)
+	if err != nil {
+		return err
+	}
+	p.ID = id
+
+	_, err = s.collection.InsertOne(ctx, bson.NewDocument(
+		bson.EC.Int32("ID", p.ID),
+		bson.EC.String("slug", p.Slug),
+		bson.EC.String("title", p.Title),
+	))
+	return err
+}
+
+// nextID returns one greater than the highest ID currently stored in the
+// collection (or 1 if it's empty), standing in for the auto-increment
+// Postgres gets for free from its SERIAL column.
+func (s *MongoStore) nextID(ctx context.Context) (int32, error) {
+	opts := options.FindOne().SetSort(bson.NewDocument(bson.EC.Int32("ID", -1)))
+	result := s.collection.FindOne(ctx, bson.NewDocument(), opts)
+
+	var last Post
+	switch err := result.Decode(&last); err {
+	case nil:
+		return last.ID + 1, nil
+	case mongo.ErrNoDocuments:
+		return 1, nil
+	default:
+		return 0, err
+	}
+}
+
+func (s *MongoStore) Update(ctx context.Context, slug, title string) error {
+	_, err := s.collection.UpdateOne(
+		ctx,
+		bson.NewDocument(bson.EC.String("slug", slug)),
+		bson.NewDocument(bson.EC.SubDocumentFromElements("$set",
+			bson.EC.String("title", title),
+		)),
+	)
+	return err
+}
+
+func (s *MongoStore) Delete(ctx context.Context, slug string) (bool, error) {
+	res, err := s.collection.DeleteOne(ctx, bson.NewDocument(bson.EC.String("slug", slug)))
+	if err != nil {
+		return false, err
+	}
+	return res.DeletedCount > 0, nil
+}
+
+func (s *MongoStore) DeleteAll(ctx context.Context) error {
+	_, err := s.collection.DeleteMany(ctx, bson.NewDocument())
+	return err
+}
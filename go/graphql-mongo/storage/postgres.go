@@ -0,0 +1,139 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/lib/pq"
+	"github.com/mattes/migrate"
+	_ "github.com/mattes/migrate/database/postgres"
+	_ "github.com/mattes/migrate/source/file"
+)
+
+// migrationsPath points at the SQL migrations run by NewPostgresStore,
+// relative to the working directory the Now function is deployed from.
+const migrationsPath = "file://migrations"
+
+// PostgresStore implements PostStore against a Postgres database via
+// lib/pq, for deployments that prefer managed Postgres over MongoDB Atlas.
+type PostgresStore struct {
+	db *sql.DB
+}
+
+// NewPostgresStore opens a connection pool to databaseURL (the standard
+// DATABASE_URL env var), runs any pending migrations under migrations/, and
+// returns a PostgresStore ready to serve posts.
+func NewPostgresStore(databaseURL string) (*PostgresStore, error) {
+	db, err := sql.Open("postgres", databaseURL)
+	if err != nil {
+		return nil, fmt.Errorf("storage: opening postgres: %v", err)
+	}
+	if err := db.Ping(); err != nil {
+		return nil, fmt.Errorf("storage: pinging postgres: %v", err)
+	}
+
+	if err := runMigrations(databaseURL); err != nil {
+		return nil, err
+	}
+
+	return &PostgresStore{db: db}, nil
+}
+
+// runMigrations applies every migration under migrations/ that hasn't run
+// yet, following the same up-on-startup flow used elsewhere for Postgres
+// based Now functions.
+func runMigrations(databaseURL string) error {
+	m, err := migrate.New(migrationsPath, databaseURL)
+	if err != nil {
+		return fmt.Errorf("storage: loading migrations: %v", err)
+	}
+	if err := m.Up(); err != nil && err != migrate.ErrNoChange {
+		return fmt.Errorf("storage: running migrations: %v", err)
+	}
+	return nil
+}
+
+// Ping verifies the connection pool can still reach Postgres, for the
+// /healthz endpoint.
+func (s *PostgresStore) Ping(ctx context.Context) error {
+	return s.db.PingContext(ctx)
+}
+
+func (s *PostgresStore) GetBySlug(ctx context.Context, slug string) (*Post, error) {
+	p := &Post{}
+	row := s.db.QueryRowContext(ctx, `SELECT id, slug, title FROM posts WHERE slug = $1`, slug)
+	switch err := row.Scan(&p.ID, &p.Slug, &p.Title); err {
+	case nil:
+		return p, nil
+	case sql.ErrNoRows:
+		return nil, nil
+	default:
+		return nil, err
+	}
+}
+
+func (s *PostgresStore) BatchGetBySlugs(ctx context.Context, slugs []string) (map[string]*Post, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT id, slug, title FROM posts WHERE slug = ANY($1)`, pq.Array(slugs))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	found := make(map[string]*Post, len(slugs))
+	for rows.Next() {
+		p := &Post{}
+		if err := rows.Scan(&p.ID, &p.Slug, &p.Title); err != nil {
+			return nil, err
+		}
+		found[p.Slug] = p
+	}
+	return found, rows.Err()
+}
+
+func (s *PostgresStore) List(ctx context.Context) ([]*Post, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT id, slug, title FROM posts`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var posts []*Post
+	for rows.Next() {
+		p := &Post{}
+		if err := rows.Scan(&p.ID, &p.Slug, &p.Title); err != nil {
+			return nil, err
+		}
+		posts = append(posts, p)
+	}
+	return posts, rows.Err()
+}
+
+// Insert assigns p.ID from the posts.id SERIAL column via RETURNING,
+// rather than taking whatever p.ID already holds - the caller never has a
+// real id to offer before the row exists.
+func (s *PostgresStore) Insert(ctx context.Context, p *Post) error {
+	return s.db.QueryRowContext(ctx,
+		`INSERT INTO posts (slug, title) VALUES ($1, $2) RETURNING id`,
+		p.Slug, p.Title,
+	).Scan(&p.ID)
+}
+
+func (s *PostgresStore) Update(ctx context.Context, slug, title string) error {
+	_, err := s.db.ExecContext(ctx, `UPDATE posts SET title = $1 WHERE slug = $2`, title, slug)
+	return err
+}
+
+func (s *PostgresStore) Delete(ctx context.Context, slug string) (bool, error) {
+	res, err := s.db.ExecContext(ctx, `DELETE FROM posts WHERE slug = $1`, slug)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+func (s *PostgresStore) DeleteAll(ctx context.Context) error {
+	_, err := s.db.ExecContext(ctx, `TRUNCATE posts`)
+	return err
+}
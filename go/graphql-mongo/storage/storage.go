@@ -0,0 +1,59 @@
+// Package storage abstracts the durable store behind the post resolvers so
+// package index does not depend on a specific database driver. Two
+// implementations are provided, MongoStore and PostgresStore, selected at
+// startup by NewStore via the STORAGE_DRIVER env var.
+package storage
+
+import (
+	"context"
+	"fmt"
+	"os"
+)
+
+// Post is the storage layer's representation of a post, decoupled from any
+// particular driver's document/row type.
+type Post struct {
+	ID    int32
+	Slug  string
+	Title string
+}
+
+// PostStore is the interface the resolvers in package index program
+// against. MongoStore and PostgresStore both implement it.
+type PostStore interface {
+	// GetBySlug returns the post with the given slug, or nil if none exists.
+	GetBySlug(ctx context.Context, slug string) (*Post, error)
+	// BatchGetBySlugs returns every matching post keyed by slug, used by the
+	// loader package to satisfy a batch of Load calls with one query.
+	BatchGetBySlugs(ctx context.Context, slugs []string) (map[string]*Post, error)
+	// List returns every post.
+	List(ctx context.Context) ([]*Post, error)
+	// Insert adds a new post, assigning p.ID (callers should leave it zero;
+	// Postgres assigns it from its SERIAL column, Mongo from its own
+	// counter since it has no equivalent primitive).
+	Insert(ctx context.Context, p *Post) error
+	// Update overwrites the title of the post with the given slug.
+	Update(ctx context.Context, slug, title string) error
+	// Delete removes the post with the given slug, reporting whether one
+	// was actually removed.
+	Delete(ctx context.Context, slug string) (bool, error)
+	// DeleteAll removes every post. Used to reset the mock data on startup.
+	DeleteAll(ctx context.Context) error
+	// Ping reports whether the store can still reach its backing database,
+	// for the /healthz endpoint.
+	Ping(ctx context.Context) error
+}
+
+// NewStore constructs the PostStore selected by the STORAGE_DRIVER env var
+// ("mongo" or "postgres"), defaulting to "mongo" to match the behavior
+// before STORAGE_DRIVER existed.
+func NewStore() (PostStore, error) {
+	switch driver := os.Getenv("STORAGE_DRIVER"); driver {
+	case "", "mongo":
+		return NewMongoStore()
+	case "postgres":
+		return NewPostgresStore(os.Getenv("DATABASE_URL"))
+	default:
+		return nil, fmt.Errorf("storage: unknown STORAGE_DRIVER %q", driver)
+	}
+}
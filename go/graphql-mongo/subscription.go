@@ -0,0 +1,98 @@
+package index
+
+import (
+	"context"
+	"net/http"
+	"sync"
+
+	"github.com/99designs/gqlgen/handler"
+)
+
+//////// SUBSCRIPTIONS ////////
+
+// subscriber is one postAdded registration. done is closed exactly once, by
+// removeSubscriber, to tell publishPostAdded to stop trying to send on ch
+// instead of leaving it to guess from ch itself - ch is never closed, so a
+// publish racing a removal can never send on a closed channel.
+type subscriber struct {
+	ch   chan *Post
+	done chan struct{}
+}
+
+// postAddedSubscribers holds the per-slug subscribers that publishPostAdded
+// fans a newly created post out to. A nil/empty slug key matches every
+// post. It's read and written from the subscription-registration path,
+// removeSubscriber's ctx.Done() goroutine, and publishPostAdded (itself
+// called from mutation resolvers), so postAddedSubscribersMu guards every
+// access.
+var (
+	postAddedSubscribersMu sync.Mutex
+	postAddedSubscribers   = make(map[string][]*subscriber)
+)
+
+// subscribePostAdded registers a channel for the postAdded subscription,
+// deregistering it once ctx (the subscription's request context) is done.
+func subscribePostAdded(ctx context.Context, slug *string) <-chan *Post {
+	key := ""
+	if slug != nil {
+		key = *slug
+	}
+
+	sub := &subscriber{ch: make(chan *Post, 1), done: make(chan struct{})}
+
+	postAddedSubscribersMu.Lock()
+	postAddedSubscribers[key] = append(postAddedSubscribers[key], sub)
+	postAddedSubscribersMu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+		removeSubscriber(key, sub)
+	}()
+
+	return sub.ch
+}
+
+func removeSubscriber(key string, sub *subscriber) {
+	postAddedSubscribersMu.Lock()
+	defer postAddedSubscribersMu.Unlock()
+
+	subs := postAddedSubscribers[key]
+	for i, s := range subs {
+		if s == sub {
+			postAddedSubscribers[key] = append(subs[:i], subs[i+1:]...)
+			close(sub.done)
+			return
+		}
+	}
+}
+
+// publishPostAdded fans p out to every postAdded subscriber watching its
+// slug as well as every subscriber with no slug filter. The subscriber
+// slice is copied out under the lock so the sends below don't hold it.
+// Each send races sub.done instead of just doing sub.ch <- p, so a
+// subscriber that removeSubscriber is concurrently tearing down gets its
+// post dropped instead of a panic (if done wins) or a permanently blocked
+// publisher (once the subscriber stops being read).
+func publishPostAdded(p *Post) {
+	postAddedSubscribersMu.Lock()
+	subs := make([]*subscriber, 0, len(postAddedSubscribers[p.Slug])+len(postAddedSubscribers[""]))
+	subs = append(subs, postAddedSubscribers[p.Slug]...)
+	subs = append(subs, postAddedSubscribers[""]...)
+	postAddedSubscribersMu.Unlock()
+
+	for _, sub := range subs {
+		select {
+		case sub.ch <- p:
+		case <-sub.done:
+		}
+	}
+}
+
+// SubscriptionHandler upgrades the request to a WebSocket and speaks the
+// graphql-ws protocol for `subscription` operations, via the same
+// executable schema Handler serves over HTTP. It is wired up as its own
+// route alongside Handler, since Now functions map one handler per entry
+// point.
+func SubscriptionHandler(w http.ResponseWriter, r *http.Request) {
+	handler.GraphQL(execSchema).ServeHTTP(w, r)
+}